@@ -0,0 +1,79 @@
+// Package backoff paces how often a watch gets re-checked: normal cadence
+// while things are healthy, TTL-aware waits after a negative answer, and
+// exponential backoff with jitter on transient errors.
+package backoff
+
+import (
+	"math/rand"
+	"time"
+)
+
+// FailureWarningThreshold is how many consecutive transient failures a
+// watch tolerates before it notifies instead of just quietly retrying.
+const FailureWarningThreshold = 5
+
+// Pacer decides how long to wait before the next poll of a watch.
+type Pacer struct {
+	base   time.Duration
+	max    time.Duration
+	fails  int
+	warned bool
+}
+
+// New builds a Pacer with the given base interval and backoff ceiling.
+func New(base, max time.Duration) *Pacer {
+	if max < base {
+		max = base
+	}
+	return &Pacer{base: base, max: max}
+}
+
+// Next computes the wait before the next poll given the outcome of the one
+// that just ran, and whether a consecutive-failure warning should fire now.
+func (p *Pacer) Next(transientErr bool, negativeTTL time.Duration) (wait time.Duration, shouldWarn bool) {
+	if transientErr {
+		p.fails++
+		shouldWarn = p.fails == FailureWarningThreshold && !p.warned
+		if shouldWarn {
+			p.warned = true
+		}
+		return p.backoffInterval(), shouldWarn
+	}
+
+	p.fails = 0
+	p.warned = false
+
+	wait = p.base
+	if negativeTTL > wait {
+		wait = negativeTTL
+	}
+	if wait > p.max {
+		wait = p.max
+	}
+	return wait, false
+}
+
+// backoffInterval doubles the base interval per consecutive failure,
+// capped at max, then jitters by up to +/-10% so a fleet of watches that
+// all started failing at once doesn't retry in lockstep.
+func (p *Pacer) backoffInterval() time.Duration {
+	interval := p.base
+	for i := 0; i < p.fails && interval < p.max; i++ {
+		interval *= 2
+	}
+	if interval > p.max {
+		interval = p.max
+	}
+	return jitter(interval)
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	spread := d / 5 // 20%
+	if spread <= 0 {
+		return d
+	}
+	return d - spread/2 + time.Duration(rand.Int63n(int64(spread)+1))
+}
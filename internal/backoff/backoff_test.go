@@ -0,0 +1,103 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPacerBackoffIntervalDoublesAndCaps(t *testing.T) {
+	cases := []struct {
+		name  string
+		fails int
+		base  time.Duration
+		max   time.Duration
+		want  time.Duration
+	}{
+		{"no failures yet", 0, time.Minute, time.Hour, time.Minute},
+		{"one failure doubles", 1, time.Minute, time.Hour, 2 * time.Minute},
+		{"three failures is 8x base", 3, time.Minute, time.Hour, 8 * time.Minute},
+		{"capped at max", 10, time.Minute, 5 * time.Minute, 5 * time.Minute},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := New(tc.base, tc.max)
+			p.fails = tc.fails
+
+			// jitter() spreads the result by up to +/-10%, so check the
+			// interval lands in that band rather than matching it exactly.
+			spread := tc.want / 5
+			lo, hi := tc.want-spread/2, tc.want+spread/2
+			for i := 0; i < 20; i++ {
+				got := p.backoffInterval()
+				if got < lo || got > hi {
+					t.Fatalf("backoffInterval() = %v, want in [%v, %v]", got, lo, hi)
+				}
+			}
+		})
+	}
+}
+
+func TestPacerNextResetsOnSuccess(t *testing.T) {
+	p := New(time.Minute, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if _, _ = p.Next(true, 0); p.fails != i+1 {
+			t.Fatalf("after %d transient failures, fails = %d, want %d", i+1, p.fails, i+1)
+		}
+	}
+
+	wait, shouldWarn := p.Next(false, 0)
+	if p.fails != 0 {
+		t.Fatalf("fails = %d after a success, want 0", p.fails)
+	}
+	if shouldWarn {
+		t.Fatal("Next() returned shouldWarn on a success, want false")
+	}
+	if wait != time.Minute {
+		t.Fatalf("Next() wait = %v on a clean success, want base %v", wait, time.Minute)
+	}
+}
+
+func TestPacerNextWarnsOnceAtThreshold(t *testing.T) {
+	p := New(time.Minute, time.Hour)
+
+	var warnedAt int
+	for i := 1; i <= FailureWarningThreshold+2; i++ {
+		_, shouldWarn := p.Next(true, 0)
+		if shouldWarn {
+			warnedAt = i
+		}
+	}
+	if warnedAt != FailureWarningThreshold {
+		t.Fatalf("shouldWarn fired at failure #%d, want #%d", warnedAt, FailureWarningThreshold)
+	}
+
+	// A further transient failure shouldn't warn again until a success
+	// resets p.warned.
+	if _, shouldWarn := p.Next(true, 0); shouldWarn {
+		t.Fatal("Next() warned a second time past the threshold, want only once")
+	}
+}
+
+func TestPacerNextUsesNegativeTTLWhenLarger(t *testing.T) {
+	p := New(time.Minute, time.Hour)
+
+	wait, _ := p.Next(false, 10*time.Minute)
+	if wait != 10*time.Minute {
+		t.Fatalf("Next() wait = %v, want negativeTTL %v to win over base", wait, 10*time.Minute)
+	}
+
+	wait, _ = p.Next(false, 30*time.Second)
+	if wait != time.Minute {
+		t.Fatalf("Next() wait = %v, want base %v when negativeTTL is smaller", wait, time.Minute)
+	}
+}
+
+func TestPacerNextCapsNegativeTTLAtMax(t *testing.T) {
+	p := New(time.Minute, 5*time.Minute)
+
+	wait, _ := p.Next(false, time.Hour)
+	if wait != 5*time.Minute {
+		t.Fatalf("Next() wait = %v, want capped at max %v", wait, 5*time.Minute)
+	}
+}
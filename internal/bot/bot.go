@@ -0,0 +1,305 @@
+// Package bot drives the interactive Telegram commands (/watch, /status,
+// /stop, /list, /recheck) on top of a registry.Registry and a
+// scheduler.Scheduler.
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/notifier"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/registry"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/resolver"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/scheduler"
+)
+
+// pollRetryDelay is how long RunPolling waits after a failed getUpdates
+// round before retrying, so a persistent outage logs a few errors a minute
+// instead of spinning the CPU on a tight request loop.
+const pollRetryDelay = 5 * time.Second
+
+// telegramUpdate is the subset of Telegram's Update object the command
+// router cares about, whether it arrives via getUpdates or a webhook.
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+		Text string `json:"text"`
+	} `json:"message"`
+}
+
+type getUpdatesResponse struct {
+	OK     bool             `json:"ok"`
+	Result []telegramUpdate `json:"result"`
+}
+
+// Bot drives the interactive Telegram commands on top of a
+// registry.Registry, either by long-polling getUpdates or by serving a
+// webhook.
+type Bot struct {
+	token        string
+	allowedChats map[string]bool // empty means allow any chat
+	registry     *registry.Registry
+	scheduler    *scheduler.Scheduler
+	// WebhookSecret, when set, is the value Telegram is told (via
+	// RegisterWebhook's secret_token) to echo back on the
+	// X-Telegram-Bot-Api-Secret-Token header of every webhook POST.
+	// WebhookHandler rejects requests that don't present it, so the
+	// allowed-chat-ID list can't be bypassed by forging updates straight
+	// at the listen address.
+	WebhookSecret string
+}
+
+func New(token string, allowedChatIDs []string, reg *registry.Registry, sched *scheduler.Scheduler) *Bot {
+	allowed := make(map[string]bool, len(allowedChatIDs))
+	for _, id := range allowedChatIDs {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			allowed[id] = true
+		}
+	}
+	return &Bot{token: token, allowedChats: allowed, registry: reg, scheduler: sched}
+}
+
+func (b *Bot) authorized(chatID string) bool {
+	if len(b.allowedChats) == 0 {
+		return true
+	}
+	return b.allowedChats[chatID]
+}
+
+// handleCommand parses and runs a single chat message, returning the text to
+// reply with.
+func (b *Bot) handleCommand(chatID, text string) string {
+	if !b.authorized(chatID) {
+		log.Printf("Rejected command from unauthorized chat %s: %q", chatID, text)
+		return "You're not authorized to use this bot."
+	}
+
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch fields[0] {
+	case "/watch":
+		return b.cmdWatch(chatID, fields[1:])
+	case "/stop":
+		return b.cmdStop(fields[1:])
+	case "/list":
+		return b.cmdList()
+	case "/status":
+		return b.cmdStatus(fields[1:])
+	case "/recheck":
+		return b.cmdRecheck(fields[1:])
+	default:
+		return "Unknown command. Try /watch, /status, /list, /stop or /recheck."
+	}
+}
+
+func (b *Bot) cmdWatch(chatID string, args []string) string {
+	if len(args) == 0 {
+		return "Usage: /watch <domain> [A,MX,...]"
+	}
+	domain := args[0]
+	types := resolver.DefaultTypes
+	if len(args) > 1 {
+		types = parseRecordTypeList(args[1])
+		if len(types) == 0 {
+			return fmt.Sprintf("Couldn't parse record types %q.", args[1])
+		}
+	}
+
+	w := &registry.Watch{Domain: domain, RecordTypes: types, DNSServers: "1.1.1.1:53", ChatID: chatID}
+	if err := b.registry.Add(w); err != nil {
+		return fmt.Sprintf("Failed to save watch: %v", err)
+	}
+	b.scheduler.WatchAdded(w)
+	return fmt.Sprintf("Watching %s for %s.", domain, formatRecordTypeList(types))
+}
+
+func (b *Bot) cmdStop(args []string) string {
+	if len(args) == 0 {
+		return "Usage: /stop <domain>"
+	}
+	domain := args[0]
+	if _, ok := b.registry.Get(domain); !ok {
+		return fmt.Sprintf("Not watching %s.", domain)
+	}
+	if err := b.registry.Remove(domain); err != nil {
+		return fmt.Sprintf("Failed to remove watch: %v", err)
+	}
+	b.scheduler.WatchRemoved(domain)
+	return fmt.Sprintf("Stopped watching %s.", domain)
+}
+
+func (b *Bot) cmdList() string {
+	watches := b.registry.List()
+	if len(watches) == 0 {
+		return "No active watches."
+	}
+	var lines []string
+	for _, w := range watches {
+		lines = append(lines, fmt.Sprintf("- %s (%s)", w.Domain, formatRecordTypeList(w.RecordTypes)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *Bot) cmdStatus(args []string) string {
+	if len(args) == 0 {
+		return b.cmdList()
+	}
+	domain := args[0]
+	w, ok := b.registry.Get(domain)
+	if !ok {
+		return fmt.Sprintf("Not watching %s.", domain)
+	}
+	if w.LastStatus == "" {
+		return fmt.Sprintf("No check has completed yet for %s.", domain)
+	}
+	return w.LastStatus
+}
+
+func (b *Bot) cmdRecheck(args []string) string {
+	if len(args) == 0 {
+		return "Usage: /recheck <domain>"
+	}
+	domain := args[0]
+	if _, ok := b.registry.Get(domain); !ok {
+		return fmt.Sprintf("Not watching %s.", domain)
+	}
+	b.scheduler.RequestRecheck(domain)
+	return fmt.Sprintf("Rechecking %s now.", domain)
+}
+
+func parseRecordTypeList(raw string) []resolver.RecordType {
+	var out []resolver.RecordType
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		for _, known := range resolver.DefaultTypes {
+			if string(known) == part {
+				out = append(out, known)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func formatRecordTypeList(types []resolver.RecordType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ",")
+}
+
+// RunPolling long-polls getUpdates and dispatches each message to
+// handleCommand, replying in the same chat. It never returns: there is no
+// cancellation, and the loop runs for the life of the process.
+func (b *Bot) RunPolling() {
+	var offset int64
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/getUpdates", b.token)
+
+	for {
+		resp, err := http.Get(fmt.Sprintf("%s?offset=%d&timeout=30", apiURL, offset))
+		if err != nil {
+			log.Printf("getUpdates error: %v", err)
+			time.Sleep(pollRetryDelay)
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			log.Printf("reading getUpdates response: %v", err)
+			time.Sleep(pollRetryDelay)
+			continue
+		}
+
+		var parsed getUpdatesResponse
+		if err := json.Unmarshal(body, &parsed); err != nil || !parsed.OK {
+			log.Printf("unexpected getUpdates response: %s", body)
+			time.Sleep(pollRetryDelay)
+			continue
+		}
+
+		for _, u := range parsed.Result {
+			offset = u.UpdateID + 1
+			b.dispatch(u)
+		}
+	}
+}
+
+func (b *Bot) dispatch(u telegramUpdate) {
+	if u.Message == nil || u.Message.Text == "" {
+		return
+	}
+	chatID := strconv.FormatInt(u.Message.Chat.ID, 10)
+	reply := b.handleCommand(chatID, u.Message.Text)
+	if reply == "" {
+		return
+	}
+	if err := notifier.SendTelegramMessage(b.token, chatID, reply); err != nil {
+		log.Printf("Error replying to chat %s: %v", chatID, err)
+	}
+}
+
+// WebhookHandler serves Telegram updates posted to a --listen address
+// instead of long-polling, for deployments behind a public BASE_URL. It
+// rejects any request that doesn't carry the secret token RegisterWebhook
+// gave Telegram, so an attacker who finds the listen address can't forge
+// chat.id values (which otherwise drive authorized and every command).
+func (b *Bot) WebhookHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if b.WebhookSecret != "" && r.Header.Get("X-Telegram-Bot-Api-Secret-Token") != b.WebhookSecret {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		defer r.Body.Close()
+		var u telegramUpdate
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		b.dispatch(u)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// GenerateWebhookSecret returns a random token suitable for Telegram's
+// setWebhook secret_token parameter (1-256 chars of A-Z, a-z, 0-9, _ and -).
+func GenerateWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// RegisterWebhook tells Telegram where to POST updates, and what secret
+// token to echo back on every one so WebhookHandler can verify the sender.
+func RegisterWebhook(token, baseURL, secret string) error {
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/setWebhook?url=%s/telegram/webhook&secret_token=%s", token, baseURL, secret)
+	resp, err := http.Get(apiURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("setWebhook returned non-200 status: %s", resp.Status)
+	}
+	return nil
+}
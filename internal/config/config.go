@@ -0,0 +1,142 @@
+// Package config loads config.yaml/config.json and the legacy CLI-flag
+// equivalent into the shared shape the watch/check code runs on.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/discovery"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/notifier"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/resolver"
+)
+
+// NotifierConfig is one entry under the `notifiers:` list in config.yaml or
+// config.json. Which fields matter depends on Type; unused ones are simply
+// left at their zero value.
+type NotifierConfig struct {
+	Type       string   `yaml:"type" json:"type"`
+	BotToken   string   `yaml:"bot_token,omitempty" json:"bot_token,omitempty"`
+	ChatID     string   `yaml:"chat_id,omitempty" json:"chat_id,omitempty"`
+	WebhookURL string   `yaml:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+	URL        string   `yaml:"url,omitempty" json:"url,omitempty"`
+	SMTPHost   string   `yaml:"smtp_host,omitempty" json:"smtp_host,omitempty"`
+	SMTPPort   string   `yaml:"smtp_port,omitempty" json:"smtp_port,omitempty"`
+	Username   string   `yaml:"username,omitempty" json:"username,omitempty"`
+	Password   string   `yaml:"password,omitempty" json:"password,omitempty"`
+	From       string   `yaml:"from,omitempty" json:"from,omitempty"`
+	To         []string `yaml:"to,omitempty" json:"to,omitempty"`
+}
+
+// DomainConfig is one entry under `domains:` in config.yaml/config.json: a
+// domain to watch, which record types to poll for it, and optionally what
+// those records are expected to look like once the migration is done.
+type DomainConfig struct {
+	Domain             string                    `yaml:"domain" json:"domain"`
+	RecordTypes        []resolver.RecordType     `yaml:"record_types,omitempty" json:"record_types,omitempty"`
+	DNSServers         string                    `yaml:"dns_servers,omitempty" json:"dns_servers,omitempty"`
+	IntervalMinutes    int                       `yaml:"interval_minutes,omitempty" json:"interval_minutes,omitempty"`
+	MaxIntervalMinutes int                       `yaml:"max_interval_minutes,omitempty" json:"max_interval_minutes,omitempty"`
+	Deadline           string                    `yaml:"deadline,omitempty" json:"deadline,omitempty"` // RFC3339; empty means no deadline
+	Expected           discovery.ExpectedRecords `yaml:"expected,omitempty" json:"expected,omitempty"`
+}
+
+// FileConfig is the top-level shape of config.yaml/config.json.
+type FileConfig struct {
+	Notifiers []NotifierConfig `yaml:"notifiers" json:"notifiers"`
+	Domains   []DomainConfig   `yaml:"domains" json:"domains"`
+}
+
+// SingleDomain builds the single DomainConfig entry the legacy CLI flags
+// (--domain, --dns, --interval, --max-interval) translate to, so the flag
+// path and the --config path share the exact same checking code.
+func SingleDomain(domain, dnsServers string, intervalMinutes, maxIntervalMinutes int) DomainConfig {
+	return DomainConfig{
+		Domain:             domain,
+		RecordTypes:        resolver.LegacyTypes,
+		DNSServers:         dnsServers,
+		IntervalMinutes:    intervalMinutes,
+		MaxIntervalMinutes: maxIntervalMinutes,
+	}
+}
+
+// Load reads config.yaml or config.json, picking the decoder from the file
+// extension.
+func Load(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg FileConfig
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	case ".json":
+		err = json.Unmarshal(data, &cfg)
+	default:
+		return nil, fmt.Errorf("unrecognized config extension %q (want .yaml, .yml or .json)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// BuildNotifiers turns config file entries into live notifier instances.
+func BuildNotifiers(configs []NotifierConfig) ([]notifier.Notifier, error) {
+	notifiers := make([]notifier.Notifier, 0, len(configs))
+	for _, c := range configs {
+		n, err := buildNotifier(c)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+func buildNotifier(c NotifierConfig) (notifier.Notifier, error) {
+	switch c.Type {
+	case "telegram":
+		if c.BotToken == "" || c.ChatID == "" {
+			return nil, fmt.Errorf("telegram notifier requires bot_token and chat_id")
+		}
+		return &notifier.TelegramNotifier{BotToken: c.BotToken, ChatID: c.ChatID}, nil
+	case "slack":
+		if c.WebhookURL == "" {
+			return nil, fmt.Errorf("slack notifier requires webhook_url")
+		}
+		return &notifier.SlackNotifier{WebhookURL: c.WebhookURL}, nil
+	case "webhook":
+		if c.URL == "" {
+			return nil, fmt.Errorf("webhook notifier requires url")
+		}
+		return &notifier.WebhookNotifier{URL: c.URL}, nil
+	case "email":
+		if c.SMTPHost == "" || c.From == "" || len(c.To) == 0 {
+			return nil, fmt.Errorf("email notifier requires smtp_host, from and to")
+		}
+		port := c.SMTPPort
+		if port == "" {
+			port = "587"
+		}
+		return &notifier.EmailNotifier{
+			SMTPHost: c.SMTPHost,
+			SMTPPort: port,
+			Username: c.Username,
+			Password: c.Password,
+			From:     c.From,
+			To:       c.To,
+		}, nil
+	case "dry-run":
+		return notifier.DryRunNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", c.Type)
+	}
+}
@@ -0,0 +1,182 @@
+package discovery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/resolver"
+)
+
+// ExpectedMX is one expected mail exchanger entry under a domain's
+// `expected.mx:` list.
+type ExpectedMX struct {
+	Host string `yaml:"host" json:"host"`
+	Pref uint16 `yaml:"pref" json:"pref"`
+}
+
+// ExpectedRecords is what a user declares they want to see once a domain
+// has finished propagating, per record type. A zero-value field means "no
+// expectation configured" for that type, not "expected to be empty".
+type ExpectedRecords struct {
+	A           []string     `yaml:"a,omitempty" json:"a,omitempty"`
+	AAAA        []string     `yaml:"aaaa,omitempty" json:"aaaa,omitempty"`
+	NS          []string     `yaml:"ns,omitempty" json:"ns,omitempty"`
+	CNAME       []string     `yaml:"cname,omitempty" json:"cname,omitempty"`
+	MX          []ExpectedMX `yaml:"mx,omitempty" json:"mx,omitempty"`
+	TXTContains []string     `yaml:"txt_contains,omitempty" json:"txt_contains,omitempty"`
+}
+
+// MatchStatus is how a record type's live answer compares to what was
+// declared in `expected:`.
+type MatchStatus int
+
+const (
+	StatusUnspecified MatchStatus = iota // no expectation configured for this type
+	StatusMissing                        // still NXDOMAIN / no answer anywhere
+	StatusWrong                          // present, but doesn't match what's expected
+	StatusMatch                          // present and matches
+)
+
+func (s MatchStatus) String() string {
+	switch s {
+	case StatusMissing:
+		return "still NXDOMAIN"
+	case StatusWrong:
+		return "present but wrong"
+	case StatusMatch:
+		return "matches expected"
+	default:
+		return "no expectation configured"
+	}
+}
+
+// Icon renders s as the emoji used in status messages.
+func (s MatchStatus) Icon() string {
+	switch s {
+	case StatusMatch:
+		return "✅"
+	case StatusWrong:
+		return "⚠️"
+	case StatusMissing:
+		return "⏳"
+	default:
+		return "·"
+	}
+}
+
+// HasExpectation reports whether exp declares anything for rt.
+func HasExpectation(rt resolver.RecordType, exp ExpectedRecords) bool {
+	switch rt {
+	case resolver.A:
+		return len(exp.A) > 0
+	case resolver.AAAA:
+		return len(exp.AAAA) > 0
+	case resolver.NS:
+		return len(exp.NS) > 0
+	case resolver.CNAME:
+		return len(exp.CNAME) > 0
+	case resolver.MX:
+		return len(exp.MX) > 0
+	case resolver.TXT:
+		return len(exp.TXTContains) > 0
+	default:
+		return false
+	}
+}
+
+// evaluateRecordMatch compares the live quorum state for rt against exp,
+// matching if ANY resolver's answer satisfies the expectation (propagation
+// is inherently inconsistent across resolvers while it's in flight).
+func evaluateRecordMatch(rt resolver.RecordType, exp ExpectedRecords, q *recordQuorum) MatchStatus {
+	if !HasExpectation(rt, exp) {
+		return StatusUnspecified
+	}
+	if len(q.FoundOn) == 0 {
+		return StatusMissing
+	}
+	for _, values := range q.FoundOn {
+		if recordValuesMatch(rt, exp, values) {
+			return StatusMatch
+		}
+	}
+	return StatusWrong
+}
+
+func recordValuesMatch(rt resolver.RecordType, exp ExpectedRecords, values []string) bool {
+	switch rt {
+	case resolver.A:
+		return containsAll(values, exp.A)
+	case resolver.AAAA:
+		return containsAll(values, exp.AAAA)
+	case resolver.CNAME:
+		return containsAll(values, exp.CNAME)
+	case resolver.NS:
+		return containsAll(values, exp.NS)
+	case resolver.MX:
+		return mxValuesMatch(values, exp.MX)
+	case resolver.TXT:
+		return txtContainsAll(values, exp.TXTContains)
+	default:
+		return false
+	}
+}
+
+// containsAll reports whether every value in want shows up somewhere in got
+// (order and extra answers don't matter - what matters is the expected
+// value is actually live).
+func containsAll(got, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			if strings.EqualFold(g, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func txtContainsAll(got, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			if strings.Contains(g, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// mxValuesMatch checks that every expected host/pref pair shows up among
+// the formatted "host (pref N)" answer values resolver.Query produces.
+func mxValuesMatch(got []string, want []ExpectedMX) bool {
+	for _, w := range want {
+		formatted := formatMX(w)
+		found := false
+		for _, g := range got {
+			if strings.EqualFold(g, formatted) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// formatMX renders an ExpectedMX the same way a live *dns.MX answer is
+// rendered, so the two can be compared as plain strings.
+func formatMX(mx ExpectedMX) string {
+	return fmt.Sprintf("%s (pref %d)", mx.Host, mx.Pref)
+}
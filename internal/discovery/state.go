@@ -0,0 +1,240 @@
+// Package discovery tracks per-resolver, per-record-type propagation
+// progress for a domain, and evaluates that progress against either a
+// simple "found everywhere" quorum or a declared set of expected values.
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/resolver"
+)
+
+// recordQuorum tracks, for a single record type, which resolvers currently
+// return an answer and which still come back NXDOMAIN/stale.
+type recordQuorum struct {
+	Type    resolver.RecordType
+	FoundOn map[string][]string // resolver address -> answer values
+	StaleOn map[string]string   // resolver address -> reason (NXDOMAIN, error text)
+}
+
+func newRecordQuorum(rt resolver.RecordType) *recordQuorum {
+	return &recordQuorum{
+		Type:    rt,
+		FoundOn: make(map[string][]string),
+		StaleOn: make(map[string]string),
+	}
+}
+
+// Total is how many resolvers have weighed in (found or stale) so far.
+func (q *recordQuorum) Total() int {
+	return len(q.FoundOn) + len(q.StaleOn)
+}
+
+// State tracks per-resolver, per-record-type propagation progress for one
+// domain across the whole set of resolvers being polled.
+type State struct {
+	mu          sync.Mutex
+	domain      string
+	resolvers   []resolver.NamedResolver
+	recordTypes []resolver.RecordType
+	quorum      map[resolver.RecordType]*recordQuorum
+
+	// lastTransientErr and lastNegativeTTL summarize the most recent poll,
+	// for the scheduler's backoff/TTL-aware pacing decisions.
+	lastTransientErr bool
+	lastNegativeTTL  time.Duration
+}
+
+// New builds a State that tracks domain across resolvers for recordTypes.
+func New(domain string, resolvers []resolver.NamedResolver, recordTypes []resolver.RecordType) *State {
+	quorum := make(map[resolver.RecordType]*recordQuorum, len(recordTypes))
+	for _, rt := range recordTypes {
+		quorum[rt] = newRecordQuorum(rt)
+	}
+	return &State{
+		domain:      domain,
+		resolvers:   resolvers,
+		recordTypes: recordTypes,
+		quorum:      quorum,
+	}
+}
+
+// Domain returns the domain this state tracks.
+func (s *State) Domain() string {
+	return s.domain
+}
+
+// Poll queries every resolver for every record type in parallel and folds
+// the results into the quorum state, returning the set of record types that
+// newly reached full quorum (found on every resolver) during this pass.
+func (s *State) Poll(ctx context.Context) []resolver.RecordType {
+	type result struct {
+		resolver string
+		rt       resolver.RecordType
+		answer   resolver.Answer
+	}
+
+	results := make(chan result, len(s.resolvers)*len(s.recordTypes))
+	var wg sync.WaitGroup
+	for _, r := range s.resolvers {
+		for _, rt := range s.recordTypes {
+			wg.Add(1)
+			go func(r resolver.NamedResolver, rt resolver.RecordType) {
+				defer wg.Done()
+				results <- result{resolver: r.Address, rt: rt, answer: resolver.Query(ctx, r.Address, s.domain, rt)}
+			}(r, rt)
+		}
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var newlyComplete []resolver.RecordType
+	wasComplete := make(map[resolver.RecordType]bool, len(s.recordTypes))
+
+	s.mu.Lock()
+	for rt, q := range s.quorum {
+		wasComplete[rt] = len(q.FoundOn) == len(s.resolvers)
+	}
+	var anyTransient bool
+	var maxNegativeTTL time.Duration
+	for res := range results {
+		q := s.quorum[res.rt]
+		// Each resolver sits in exactly one of FoundOn/StaleOn, by its latest answer.
+		delete(q.FoundOn, res.resolver)
+		switch {
+		case res.answer.Err != nil:
+			q.StaleOn[res.resolver] = res.answer.Err.Error()
+			if res.answer.Transient {
+				anyTransient = true
+			}
+		case res.answer.NXDomain:
+			q.StaleOn[res.resolver] = "NXDOMAIN"
+			if res.answer.NegativeTTL > maxNegativeTTL {
+				maxNegativeTTL = res.answer.NegativeTTL
+			}
+		case len(res.answer.Values) == 0:
+			q.StaleOn[res.resolver] = "no answer"
+			if res.answer.NegativeTTL > maxNegativeTTL {
+				maxNegativeTTL = res.answer.NegativeTTL
+			}
+		default:
+			delete(q.StaleOn, res.resolver)
+			q.FoundOn[res.resolver] = res.answer.Values
+		}
+	}
+	s.lastTransientErr = anyTransient
+	s.lastNegativeTTL = maxNegativeTTL
+
+	for rt, q := range s.quorum {
+		nowComplete := len(q.FoundOn) == len(s.resolvers)
+		if nowComplete && !wasComplete[rt] {
+			newlyComplete = append(newlyComplete, rt)
+		}
+	}
+	s.mu.Unlock()
+
+	return newlyComplete
+}
+
+// PacingHint reports whether the last Poll() hit a transient error (the
+// caller should back off) and, if not, how long the longest negative cache
+// TTL seen was (the caller shouldn't re-check sooner than that).
+func (s *State) PacingHint() (transientErr bool, negativeTTL time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastTransientErr, s.lastNegativeTTL
+}
+
+// FullyPropagated reports whether every record type has an answer on every
+// configured resolver.
+func (s *State) FullyPropagated() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, q := range s.quorum {
+		if len(q.FoundOn) != len(s.resolvers) {
+			return false
+		}
+	}
+	return true
+}
+
+// AllExpectationsMatch reports whether every record type in recordTypes
+// currently matches its configured expectation in exp.
+func (s *State) AllExpectationsMatch(exp ExpectedRecords, recordTypes []resolver.RecordType) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, rt := range recordTypes {
+		if evaluateRecordMatch(rt, exp, s.quorum[rt]) != StatusMatch {
+			return false
+		}
+	}
+	return true
+}
+
+// QuorumSnapshot is a point-in-time, lock-free copy of one record type's
+// quorum data, safe to read after Snapshot returns even while Poll keeps
+// running concurrently.
+type QuorumSnapshot struct {
+	Type    resolver.RecordType
+	FoundOn map[string][]string
+	StaleOn map[string]string
+}
+
+// Snapshot is a point-in-time, lock-free copy of a State, used by the
+// message package to render progress without reaching into State's
+// internals.
+type Snapshot struct {
+	Domain      string
+	Resolvers   int
+	RecordTypes []resolver.RecordType
+	Quorums     map[resolver.RecordType]QuorumSnapshot
+}
+
+// Snapshot copies out the current state for rendering.
+func (s *State) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quorums := make(map[resolver.RecordType]QuorumSnapshot, len(s.quorum))
+	for rt, q := range s.quorum {
+		quorums[rt] = QuorumSnapshot{
+			Type:    q.Type,
+			FoundOn: copyValues(q.FoundOn),
+			StaleOn: copyReasons(q.StaleOn),
+		}
+	}
+	return Snapshot{
+		Domain:      s.domain,
+		Resolvers:   len(s.resolvers),
+		RecordTypes: s.recordTypes,
+		Quorums:     quorums,
+	}
+}
+
+// RecordStatus reports how rt's live answers compare to exp, for the
+// expected-values reporting mode.
+func (s *State) RecordStatus(rt resolver.RecordType, exp ExpectedRecords) MatchStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return evaluateRecordMatch(rt, exp, s.quorum[rt])
+}
+
+func copyValues(m map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(m))
+	for k, v := range m {
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
+
+func copyReasons(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+// Package message renders a discovery.State's progress as the Telegram/
+// Slack/email text users actually read, kept separate from the state
+// tracking itself so the two can change independently.
+package message
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/discovery"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/resolver"
+)
+
+// formatQuorumLine renders one record type's progress, e.g.
+// "⏳ *A*: propagated on 6/8 resolvers (stale on 8.8.8.8:53, 9.9.9.9:53)".
+func formatQuorumLine(q discovery.QuorumSnapshot, total int) string {
+	icon := "⏳"
+	if len(q.FoundOn) == total {
+		icon = "✅"
+	}
+	line := fmt.Sprintf("%s *%s*: propagated on %d/%d resolvers", icon, q.Type, len(q.FoundOn), total)
+	if len(q.StaleOn) == 0 {
+		return line
+	}
+
+	var stale []string
+	for addr := range q.StaleOn {
+		stale = append(stale, addr)
+	}
+	sort.Strings(stale)
+	return line + fmt.Sprintf(" (stale on %s)", strings.Join(stale, ", "))
+}
+
+// FormatProgress builds the full Telegram message summarizing propagation
+// progress across every tracked record type.
+func FormatProgress(s *discovery.State) string {
+	snap := s.Snapshot()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*DNS propagation for %s*\n", snap.Domain)
+	for _, rt := range snap.RecordTypes {
+		b.WriteString(formatQuorumLine(snap.Quorums[rt], snap.Resolvers))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// FormatExpectedStatus renders each record type's status against its
+// configured expectation ("matches expected" / "present but wrong" /
+// "still NXDOMAIN"), which is what people actually want to see during a
+// migration cutover instead of a bare found/not-found flag.
+func FormatExpectedStatus(s *discovery.State, exp discovery.ExpectedRecords) string {
+	snap := s.Snapshot()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*DNS migration status for %s*\n", snap.Domain)
+	for _, rt := range snap.RecordTypes {
+		if !discovery.HasExpectation(rt, exp) {
+			continue
+		}
+		status := s.RecordStatus(rt, exp)
+		fmt.Fprintf(&b, "%s *%s*: %s\n", status.Icon(), rt, status)
+	}
+	return b.String()
+}
+
+// FormatRecordFound announces that rt just reached full quorum across all
+// resolvers for s's domain.
+func FormatRecordFound(s *discovery.State, rt resolver.RecordType) string {
+	snap := s.Snapshot()
+	q := snap.Quorums[rt]
+
+	var lines []string
+	for addr, values := range q.FoundOn {
+		lines = append(lines, fmt.Sprintf("  - `%s` via `%s`", strings.Join(values, ", "), addr))
+	}
+	sort.Strings(lines)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "✅ *%s records propagated for %s on all %d resolvers*\n", rt, snap.Domain, snap.Resolvers)
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,226 @@
+// Package notifier delivers propagation events to Telegram, Slack, email,
+// an arbitrary webhook, or stdout, depending on what a watch is configured
+// to use.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+)
+
+// Event is one thing worth telling a user about: a record type reaching
+// quorum, a full propagation, or a scheduling warning.
+type Event struct {
+	Domain  string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers an Event somewhere. Callers fan each event out to every
+// configured Notifier and aggregate their errors via NotifyAll, so a Slack
+// outage doesn't stop the Telegram notification (or vice versa).
+type Notifier interface {
+	Notify(ctx context.Context, ev Event) error
+}
+
+// NotifyAll delivers ev to every notifier, returning a combined error
+// describing any that failed (nil if all succeeded).
+func NotifyAll(ctx context.Context, notifiers []Notifier, ev Event) error {
+	var errs []error
+	for _, n := range notifiers {
+		if err := n.Notify(ctx, ev); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d notifiers failed: %v", len(errs), len(notifiers), errs)
+}
+
+// telegramMessage is the payload for the Telegram sendMessage API call.
+type telegramMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"` // Enables Markdown formatting
+}
+
+// SendTelegramMessage sends a message via the Telegram Bot API.
+func SendTelegramMessage(botToken, chatID, text string) error {
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
+
+	message := telegramMessage{
+		ChatID:    chatID,
+		Text:      text,
+		ParseMode: "Markdown",
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram API returned non-200 status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+// TelegramNotifier wraps SendTelegramMessage.
+type TelegramNotifier struct {
+	BotToken string
+	ChatID   string
+}
+
+func (n *TelegramNotifier) Notify(ctx context.Context, ev Event) error {
+	return SendTelegramMessage(n.BotToken, n.ChatID, ev.Body)
+}
+
+// SlackNotifier posts to a Slack incoming webhook URL.
+type SlackNotifier struct {
+	WebhookURL string
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, ev Event) error {
+	payload, err := json.Marshal(map[string]string{"text": fmt.Sprintf("*%s*\n%s", ev.Subject, ev.Body)})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned non-200 status: %s", resp.Status)
+	}
+	return nil
+}
+
+// WebhookNotifier POSTs the event as generic JSON to an arbitrary URL, for
+// users who want to pipe notifications into their own systems.
+type WebhookNotifier struct {
+	URL string
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned non-2xx status: %s", n.URL, resp.Status)
+	}
+	return nil
+}
+
+// EmailNotifier sends a plain-text email over SMTP with STARTTLS/implicit
+// TLS depending on the port, mirroring what most transactional mail
+// providers expect.
+type EmailNotifier struct {
+	SMTPHost string
+	SMTPPort string
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, ev Event) error {
+	addr := fmt.Sprintf("%s:%s", n.SMTPHost, n.SMTPPort)
+	auth := smtp.PlainAuth("", n.Username, n.Password, n.SMTPHost)
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n",
+		n.From, joinAddrs(n.To), ev.Subject, ev.Body)
+
+	if n.SMTPPort == "465" {
+		return n.sendImplicitTLS(addr, auth, msg)
+	}
+	return smtp.SendMail(addr, auth, n.From, n.To, []byte(msg))
+}
+
+func (n *EmailNotifier) sendImplicitTLS(addr string, auth smtp.Auth, msg string) error {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: n.SMTPHost})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	c, err := smtp.NewClient(conn, n.SMTPHost)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Auth(auth); err != nil {
+		return err
+	}
+	if err := c.Mail(n.From); err != nil {
+		return err
+	}
+	for _, to := range n.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func joinAddrs(addrs []string) string {
+	out := ""
+	for i, a := range addrs {
+		if i > 0 {
+			out += ", "
+		}
+		out += a
+	}
+	return out
+}
+
+// DryRunNotifier just logs to stdout, for testing a watch config without
+// actually spamming Telegram/Slack/email while you get it right.
+type DryRunNotifier struct{}
+
+func (DryRunNotifier) Notify(ctx context.Context, ev Event) error {
+	fmt.Printf("[dry-run] %s: %s\n%s\n", ev.Domain, ev.Subject, ev.Body)
+	return nil
+}
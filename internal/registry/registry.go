@@ -0,0 +1,102 @@
+// Package registry persists the bot's active watch list to disk.
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/resolver"
+)
+
+// Watch is one domain a user has asked the bot to keep an eye on.
+type Watch struct {
+	Domain      string                `json:"domain"`
+	RecordTypes []resolver.RecordType `json:"record_types"`
+	DNSServers  string                `json:"dns_servers"`
+	ChatID      string                `json:"chat_id"`
+	LastStatus  string                `json:"last_status,omitempty"`
+}
+
+// Registry is the on-disk set of active watches, keyed by domain so a
+// second `/watch` for the same domain just updates it in place. It's saved
+// as plain JSON at the path given to Load rather than a database, matching how
+// small this tool otherwise is.
+type Registry struct {
+	mu      sync.Mutex
+	path    string
+	Watches map[string]*Watch `json:"watches"`
+}
+
+// Load reads the registry at path, returning an empty one if it doesn't
+// exist yet.
+func Load(path string) (*Registry, error) {
+	r := &Registry{path: path, Watches: make(map[string]*Watch)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	if r.Watches == nil {
+		r.Watches = make(map[string]*Watch)
+	}
+	return r, nil
+}
+
+// save serializes the registry to disk. Callers must hold r.mu: it reads
+// r.Watches directly rather than taking its own lock so mutate-then-save
+// call sites can do both under a single critical section.
+func (r *Registry) save() error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+func (r *Registry) Add(w *Watch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Watches[strings.ToLower(w.Domain)] = w
+	return r.save()
+}
+
+func (r *Registry) Remove(domain string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.Watches, strings.ToLower(domain))
+	return r.save()
+}
+
+func (r *Registry) Get(domain string) (*Watch, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w, ok := r.Watches[strings.ToLower(domain)]
+	return w, ok
+}
+
+func (r *Registry) List() []*Watch {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]*Watch, 0, len(r.Watches))
+	for _, w := range r.Watches {
+		out = append(out, w)
+	}
+	return out
+}
+
+func (r *Registry) SetStatus(domain, status string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if w, ok := r.Watches[strings.ToLower(domain)]; ok {
+		w.LastStatus = status
+	}
+	r.save()
+}
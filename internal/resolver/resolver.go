@@ -0,0 +1,220 @@
+// Package resolver queries individual DNS servers for individual record
+// types, the one piece of wire-level work every check in this tool is
+// ultimately built on.
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// RecordType identifies which DNS RR type a check is for.
+type RecordType string
+
+const (
+	A     RecordType = "A"
+	AAAA  RecordType = "AAAA"
+	NS    RecordType = "NS"
+	MX    RecordType = "MX"
+	CNAME RecordType = "CNAME"
+	TXT   RecordType = "TXT"
+	CAA   RecordType = "CAA"
+	SOA   RecordType = "SOA"
+)
+
+// DefaultTypes is the full set of records --config watches poll for when a
+// domain entry doesn't list its own `record_types:`.
+var DefaultTypes = []RecordType{A, AAAA, NS, MX, CNAME, TXT, CAA, SOA}
+
+// LegacyTypes mirrors what the original single-domain tool checked before
+// --config existed: A, NS and MX are close to universal, unlike CNAME
+// (RFC-disallowed at a zone apex alongside other records) or CAA
+// (frequently absent). The --domain compatibility shim uses this set so
+// "fully propagated" can actually be reached for an ordinary domain.
+var LegacyTypes = []RecordType{A, NS, MX}
+
+func (rt RecordType) dnsType() uint16 {
+	switch rt {
+	case A:
+		return dns.TypeA
+	case AAAA:
+		return dns.TypeAAAA
+	case NS:
+		return dns.TypeNS
+	case MX:
+		return dns.TypeMX
+	case CNAME:
+		return dns.TypeCNAME
+	case TXT:
+		return dns.TypeTXT
+	case CAA:
+		return dns.TypeCAA
+	case SOA:
+		return dns.TypeSOA
+	default:
+		return dns.TypeNone
+	}
+}
+
+// NamedResolver is a single DNS server a domain is checked against, e.g.
+// "1.1.1.1:53" or "ns1.example.com:53".
+type NamedResolver struct {
+	Address string
+}
+
+// ParseResolvers splits a comma-separated --dns flag value into individual
+// resolver targets, defaulting the port to 53 when one isn't given.
+func ParseResolvers(raw string) []NamedResolver {
+	var out []NamedResolver
+	for _, part := range strings.Split(raw, ",") {
+		addr := strings.TrimSpace(part)
+		if addr == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "53")
+		}
+		out = append(out, NamedResolver{Address: addr})
+	}
+	return out
+}
+
+// Answer holds the outcome of querying one resolver for one record type.
+type Answer struct {
+	Values   []string
+	NXDomain bool
+	Err      error
+	// Transient is set when Err looks like a timeout, SERVFAIL, or other
+	// condition worth backing off and retrying rather than treating as a
+	// stable "not there yet" result.
+	Transient bool
+	// NegativeTTL is how long this NXDOMAIN/empty answer will sit in
+	// resolver caches, taken from the authority section's SOA record per
+	// RFC 2308. Zero when not applicable or not present in the response.
+	NegativeTTL time.Duration
+}
+
+// Query asks a single resolver for a single record type over UDP, falling
+// back to TCP on truncation. ctx bounds both attempts, so a caller whose own
+// deadline has passed doesn't keep a query outstanding past it.
+func Query(ctx context.Context, server, domain string, rt RecordType) Answer {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(domain), rt.dnsType())
+	m.RecursionDesired = true
+
+	c := &dns.Client{Timeout: 10 * time.Second}
+	in, _, err := c.ExchangeContext(ctx, m, server)
+	if err != nil {
+		return Answer{Err: err, Transient: isTransientErr(err)}
+	}
+	if in.Truncated {
+		c.Net = "tcp"
+		in, _, err = c.ExchangeContext(ctx, m, server)
+		if err != nil {
+			return Answer{Err: err, Transient: isTransientErr(err)}
+		}
+	}
+	if in.Rcode == dns.RcodeServerFailure {
+		return Answer{Err: fmt.Errorf("resolver %s returned SERVFAIL", server), Transient: true}
+	}
+	if in.Rcode == dns.RcodeNameError {
+		return Answer{NXDomain: true, NegativeTTL: negativeCacheTTL(in)}
+	}
+	if in.Rcode != dns.RcodeSuccess {
+		return Answer{Err: fmt.Errorf("resolver %s returned %s", server, dns.RcodeToString[in.Rcode])}
+	}
+	if len(in.Answer) == 0 {
+		return Answer{NegativeTTL: negativeCacheTTL(in)}
+	}
+
+	var values []string
+	for _, rr := range in.Answer {
+		values = append(values, formatRR(rr))
+	}
+	return Answer{Values: values}
+}
+
+// negativeCacheTTL reads the SOA minimum TTL out of a response's authority
+// section, which per RFC 2308 governs how long an NXDOMAIN/NODATA answer
+// will be cached - and so how long we should wait before asking again.
+func negativeCacheTTL(in *dns.Msg) time.Duration {
+	for _, rr := range in.Ns {
+		if soa, ok := rr.(*dns.SOA); ok {
+			return time.Duration(soa.Minttl) * time.Second
+		}
+	}
+	return 0
+}
+
+// isTransientErr reports whether err looks like a timeout or network hiccup
+// worth backing off and retrying, rather than a stable condition.
+func isTransientErr(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		// Dial/read/write failures (network unreachable, connection refused)
+		// count as network hiccups alongside timeouts.
+		var opErr *net.OpError
+		return netErr.Timeout() || errors.As(err, &opErr)
+	}
+	return true // unrecognized dial/transport errors are treated as transient too
+}
+
+// formatRR renders the value half of a resource record, since the caller
+// already knows the owner name and type.
+func formatRR(rr dns.RR) string {
+	switch v := rr.(type) {
+	case *dns.A:
+		return v.A.String()
+	case *dns.AAAA:
+		return v.AAAA.String()
+	case *dns.NS:
+		return v.Ns
+	case *dns.MX:
+		return fmt.Sprintf("%s (pref %d)", v.Mx, v.Preference)
+	case *dns.CNAME:
+		return v.Target
+	case *dns.TXT:
+		return strings.Join(v.Txt, " ")
+	case *dns.CAA:
+		return fmt.Sprintf("%d %s %q", v.Flag, v.Tag, v.Value)
+	case *dns.SOA:
+		return fmt.Sprintf("%s serial=%d", v.Ns, v.Serial)
+	default:
+		return rr.String()
+	}
+}
+
+// AuthoritativeNameservers looks up the NS records for domain via
+// lookupServer and returns address:53 targets (each nameserver's first
+// address from the system resolver) suitable for querying those
+// nameservers directly, bypassing whatever recursive resolvers the caller
+// is also checking. This is how we tell "registrar updated" (authoritative
+// servers answer) apart from "public resolver caches expired" (they don't,
+// yet).
+func AuthoritativeNameservers(ctx context.Context, lookupServer, domain string) ([]NamedResolver, error) {
+	answer := Query(ctx, lookupServer, domain, NS)
+	if answer.Err != nil {
+		return nil, fmt.Errorf("looking up NS for %s via %s: %w", domain, lookupServer, answer.Err)
+	}
+	if answer.NXDomain || len(answer.Values) == 0 {
+		return nil, fmt.Errorf("no NS records found for %s via %s", domain, lookupServer)
+	}
+
+	var out []NamedResolver
+	for _, host := range answer.Values {
+		addrs, err := net.LookupHost(host)
+		if err != nil || len(addrs) == 0 {
+			// The nameserver's own A record may not have propagated yet;
+			// skip it rather than failing the whole authoritative check.
+			continue
+		}
+		out = append(out, NamedResolver{Address: net.JoinHostPort(addrs[0], "53")})
+	}
+	return out, nil
+}
@@ -0,0 +1,160 @@
+// Package scheduler runs one polling worker per watched domain on behalf
+// of the interactive bot.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/backoff"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/discovery"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/message"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/notifier"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/registry"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/resolver"
+)
+
+// Scheduler is the worker manager behind the bot: it keeps one
+// discovery.State per watched domain and checks each of them on its own
+// backoff.Pacer schedule, independent of how many watches the registry
+// currently holds.
+type Scheduler struct {
+	mu       sync.Mutex
+	registry *registry.Registry
+	botToken string
+	// extraNotifiers, if any, receive every watch's events in addition to
+	// the Telegram chat that created the watch (e.g. a Slack channel
+	// configured once for the whole bot via --config).
+	extraNotifiers []notifier.Notifier
+	interval       time.Duration
+	maxInterval    time.Duration
+	cancel         map[string]context.CancelFunc
+	recheck        map[string]chan struct{}
+}
+
+// New builds a Scheduler around registry, delivering events for each watch
+// to its own Telegram chat plus extraNotifiers.
+func New(reg *registry.Registry, botToken string, extraNotifiers []notifier.Notifier, interval, maxInterval time.Duration) *Scheduler {
+	return &Scheduler{
+		registry:       reg,
+		botToken:       botToken,
+		extraNotifiers: extraNotifiers,
+		interval:       interval,
+		maxInterval:    maxInterval,
+		cancel:         make(map[string]context.CancelFunc),
+		recheck:        make(map[string]chan struct{}),
+	}
+}
+
+// Start launches a worker for every watch already in the registry. Call
+// once at startup, after which WatchAdded/WatchRemoved manage workers as
+// the bot's commands change the registry.
+func (s *Scheduler) Start() {
+	for _, w := range s.registry.List() {
+		s.WatchAdded(w)
+	}
+}
+
+func (s *Scheduler) WatchAdded(w *registry.Watch) {
+	s.mu.Lock()
+	if cancel, ok := s.cancel[w.Domain]; ok {
+		cancel() // restart with the new config
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel[w.Domain] = cancel
+	recheck := make(chan struct{}, 1)
+	s.recheck[w.Domain] = recheck
+	s.mu.Unlock()
+
+	go s.runWorker(ctx, w.Domain, recheck)
+}
+
+func (s *Scheduler) WatchRemoved(domain string) {
+	s.mu.Lock()
+	if cancel, ok := s.cancel[domain]; ok {
+		cancel()
+		delete(s.cancel, domain)
+	}
+	delete(s.recheck, domain)
+	s.mu.Unlock()
+}
+
+// RequestRecheck wakes the worker for domain out of its current backoff
+// wait so it polls immediately. The channel is buffered by one slot, so a
+// recheck requested while a poll is already in flight is queued rather than
+// dropped, but a second one on top of that is (the worker is about to wake
+// up and check anyway).
+func (s *Scheduler) RequestRecheck(domain string) {
+	s.mu.Lock()
+	recheck, ok := s.recheck[domain]
+	s.mu.Unlock()
+	if !ok {
+		log.Printf("Recheck requested for %s but no worker is running for it.", domain)
+		return
+	}
+	select {
+	case recheck <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) runWorker(ctx context.Context, domain string, recheck <-chan struct{}) {
+	w, ok := s.registry.Get(domain)
+	if !ok {
+		return
+	}
+	resolvers := resolver.ParseResolvers(w.DNSServers)
+	if len(resolvers) == 0 {
+		log.Printf("%s has no usable DNS resolvers in %q, dropping the watch.", domain, w.DNSServers)
+		s.registry.Remove(domain)
+		s.WatchRemoved(domain)
+		return
+	}
+	state := discovery.New(w.Domain, resolvers, w.RecordTypes)
+	notifiers := append([]notifier.Notifier{&notifier.TelegramNotifier{BotToken: s.botToken, ChatID: w.ChatID}}, s.extraNotifiers...)
+	pace := backoff.New(s.interval, s.maxInterval)
+
+	for {
+		pollCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		newlyComplete := state.Poll(pollCtx)
+		cancel()
+
+		notifyCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		for _, rt := range newlyComplete {
+			ev := notifier.Event{Domain: domain, Subject: fmt.Sprintf("%s records propagated", rt), Body: message.FormatRecordFound(state, rt)}
+			if err := notifier.NotifyAll(notifyCtx, notifiers, ev); err != nil {
+				log.Printf("Error notifying for %s: %v", domain, err)
+			}
+		}
+		s.registry.SetStatus(domain, message.FormatProgress(state))
+
+		if state.FullyPropagated() {
+			notifier.NotifyAll(notifyCtx, notifiers, notifier.Event{Domain: domain, Subject: "Fully propagated", Body: fmt.Sprintf("✅ *%s has fully propagated!*", domain)})
+			cancel()
+			s.registry.Remove(domain)
+			s.WatchRemoved(domain)
+			return
+		}
+
+		transientErr, negativeTTL := state.PacingHint()
+		wait, shouldWarn := pace.Next(transientErr, negativeTTL)
+		if shouldWarn {
+			notifier.NotifyAll(notifyCtx, notifiers, notifier.Event{
+				Domain:  domain,
+				Subject: "Repeated check failures",
+				Body:    fmt.Sprintf("⚠️ *%s has failed %d consecutive DNS checks.* Still retrying with backoff.", domain, backoff.FailureWarningThreshold),
+			})
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-recheck:
+		case <-time.After(wait):
+		}
+	}
+}
@@ -1,160 +1,166 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
-)
-
-// Represents the payload for the Telegram sendMessage API call.
-type telegramMessage struct {
-	ChatID    string `json:"chat_id"`
-	Text      string `json:"text"`
-	ParseMode string `json:"parse_mode"` // Enables Markdown formatting
-}
-
-// sendTelegramMessage sends a message via the Telegram Bot API.
-func sendTelegramMessage(botToken, chatID, text string) error {
-	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", botToken)
 
-	message := telegramMessage{
-		ChatID:    chatID,
-		Text:      text,
-		ParseMode: "Markdown",
-	}
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/bot"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/config"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/notifier"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/registry"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/scheduler"
+)
 
-	payload, err := json.Marshal(message)
-	if err != nil {
-		return err
-	}
+func main() {
+	// --- Command-line flags ---
+	domain := flag.String("domain", "", "The domain name to check (e.g., google.com)")
+	dnsServers := flag.String("dns", "1.1.1.1:53", "Comma-separated list of DNS resolvers to query (e.g. 1.1.1.1:53,8.8.8.8:53,ns1.example.com:53)")
+	interval := flag.Int("interval", 60, "Interval in minutes between checks")
+	maxInterval := flag.Int("max-interval", 24*60, "Maximum interval in minutes the backoff is allowed to grow to after repeated transient failures")
+	checkAuthoritative := flag.Bool("authoritative", true, "Also query the domain's authoritative nameservers directly")
+	botMode := flag.Bool("bot", false, "Run as an interactive Telegram bot instead of checking a single --domain")
+	listen := flag.String("listen", "", "Address to serve the Telegram webhook on (e.g. :8443); uses getUpdates long-polling if empty")
+	registryPath := flag.String("registry", "watches.json", "Path to the JSON file the bot persists its watch list to")
+	configPath := flag.String("config", "", "Path to a config.yaml/config.json listing notifiers to fan events out to, instead of Telegram-only")
+	dryRun := flag.Bool("dry-run", false, "Log notifications to stdout instead of delivering them, regardless of --config")
+	flag.Parse()
 
-	resp, err := http.Post(apiURL, "application/json", bytes.NewBuffer(payload))
-	if err != nil {
-		return err
+	if *botMode {
+		botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
+		if botToken == "" {
+			log.Println("Error: TELEGRAM_BOT_TOKEN must be set.")
+			os.Exit(1)
+		}
+		runBot(botToken, *listen, *registryPath, *configPath, *dryRun, time.Duration(*interval)*time.Minute, time.Duration(*maxInterval)*time.Minute)
+		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram API returned non-200 status: %s", resp.Status)
-	}
+	ctx := context.Background()
 
-	return nil
-}
+	// --config mode watches every domain declared in the file, each with
+	// its own record types/expectations, concurrently.
+	if *configPath != "" {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			log.Fatalf("Error loading %s: %v", *configPath, err)
+		}
+		if len(cfg.Domains) == 0 {
+			log.Fatalf("%s has no domains configured.", *configPath)
+		}
 
-// Keeps track of which DNS records have been found.
-type discoveryState struct {
-	aRecordFound  bool
-	nsRecordFound bool
-	mxRecordFound bool
-}
+		var notifiers []notifier.Notifier
+		if *dryRun {
+			notifiers = []notifier.Notifier{notifier.DryRunNotifier{}}
+		} else {
+			notifiers, err = config.BuildNotifiers(cfg.Notifiers)
+			if err != nil {
+				log.Fatalf("Error setting up notifiers from %s: %v", *configPath, err)
+			}
+		}
 
-func main() {
-	// --- Command-line flag for the domain ---
-	domain := flag.String("domain", "", "The domain name to check (e.g., google.com)")
-	dnsServer := flag.String("dns", "1.1.1.1:53", "The DNS server to use (host:port)")
-	interval := flag.Int("interval", 60, "Interval in minutes between checks")
-	flag.Parse()
+		var wg sync.WaitGroup
+		for _, dc := range cfg.Domains {
+			wg.Add(1)
+			go func(dc config.DomainConfig) {
+				defer wg.Done()
+				runDomainWatch(ctx, dc, notifiers, *checkAuthoritative)
+			}(dc)
+		}
+		wg.Wait()
+		return
+	}
 
 	// Exit if the domain flag is not provided.
 	if *domain == "" {
-		log.Println("Error: The --domain flag is required.")
+		log.Println("Error: The --domain flag is required (or pass --config/--bot).")
 		os.Exit(1) // Exits with a non-zero status code.
 	}
 
-	// --- Configuration ---
-	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
-	chatID := os.Getenv("TELEGRAM_CHAT_ID")
-	if dnsServer == nil || *dnsServer == "" {
-		*dnsServer = "1.1.1.1:53"
+	notifiers, err := buildSingleRunNotifiers(*dryRun)
+	if err != nil {
+		log.Fatalf("Error setting up notifiers: %v", err)
 	}
-	if interval == nil || *interval <= 0 {
-		*interval = 60 // Default to 60 minutes if invalid
+
+	// This is a compatibility shim: the legacy CLI flags just build a
+	// single-entry config and run it through the same code --config does.
+	dc := config.SingleDomain(*domain, *dnsServers, *interval, *maxInterval)
+	runDomainWatch(ctx, dc, notifiers, *checkAuthoritative)
+}
+
+// buildSingleRunNotifiers picks notifiers for the legacy flag-only path:
+// --dry-run if set, otherwise the TELEGRAM_BOT_TOKEN/TELEGRAM_CHAT_ID
+// environment variables that have always driven this mode.
+func buildSingleRunNotifiers(dryRun bool) ([]notifier.Notifier, error) {
+	if dryRun {
+		return []notifier.Notifier{notifier.DryRunNotifier{}}, nil
 	}
-	checkInterval := time.Duration(*interval) * time.Minute
-
-	// Custom resolver to use the specified DNS server.
-	resolver := &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-			d := net.Dialer{Timeout: 10 * time.Second}
-			return d.DialContext(ctx, "udp", *dnsServer)
-		},
+	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
+	chatID := os.Getenv("TELEGRAM_CHAT_ID")
+	if botToken == "" || chatID == "" {
+		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN and TELEGRAM_CHAT_ID must be set unless --dry-run is used")
 	}
+	return []notifier.Notifier{&notifier.TelegramNotifier{BotToken: botToken, ChatID: chatID}}, nil
+}
 
-	state := &discoveryState{}
-	ticker := time.NewTicker(checkInterval)
-	defer ticker.Stop()
+// runBot starts the interactive mode: a persistent watch registry driven by
+// Telegram commands, with one scheduler worker per watched domain. Updates
+// arrive either via a webhook (when listen is set, using BASE_URL to tell
+// Telegram where to reach it) or by long-polling getUpdates.
+func runBot(botToken, listen, registryPath, configPath string, dryRun bool, defaultInterval, maxInterval time.Duration) {
+	reg, err := registry.Load(registryPath)
+	if err != nil {
+		log.Fatalf("Error loading watch registry from %s: %v", registryPath, err)
+	}
 
-	log.Printf("Starting DNS propagation check for %s. Will check every %v.", *domain, checkInterval)
+	var allowedChatIDs []string
+	if raw := os.Getenv("TELEGRAM_ALLOWED_CHAT_IDS"); raw != "" {
+		allowedChatIDs = strings.Split(raw, ",")
+	}
 
-	// --- Main polling loop ---
-	for {
-		if state.aRecordFound && state.nsRecordFound && state.mxRecordFound {
-			log.Println("All DNS records found. Exiting.")
-			finalMessage := fmt.Sprintf("✅ *All records found for %s*!", *domain)
-			sendTelegramMessage(botToken, chatID, finalMessage) // Optional final notification
-			break
+	var extraNotifiers []notifier.Notifier
+	if dryRun {
+		extraNotifiers = []notifier.Notifier{notifier.DryRunNotifier{}}
+	} else if configPath != "" {
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			log.Fatalf("Error loading %s: %v", configPath, err)
 		}
-
-		// Check for A records if not already found
-		if !state.aRecordFound {
-			log.Printf("Checking A records for %s...", *domain)
-			ips, err := resolver.LookupIP(context.Background(), "ip4", *domain)
-			if err == nil && len(ips) > 0 {
-				log.Println("Found A records! Sending notification.")
-				messageText := fmt.Sprintf("✅ *A records found for %s*\n", *domain)
-				for _, ip := range ips {
-					messageText += fmt.Sprintf("  - `%s`\n", ip.String())
-				}
-				if err := sendTelegramMessage(botToken, chatID, messageText); err != nil {
-					log.Printf("Error sending Telegram notification: %v", err)
-				}
-				state.aRecordFound = true
-			}
+		extraNotifiers, err = config.BuildNotifiers(cfg.Notifiers)
+		if err != nil {
+			log.Fatalf("Error setting up notifiers from %s: %v", configPath, err)
 		}
+	}
 
-		// ... (NS and MX record checks follow the same pattern) ...
-		// Check for NS records if not already found
-		if !state.nsRecordFound {
-			log.Printf("Checking NS records for %s...", *domain)
-			ns, err := resolver.LookupNS(context.Background(), *domain)
-			if err == nil && len(ns) > 0 {
-				log.Println("Found NS records! Sending notification.")
-				messageText := fmt.Sprintf("✅ *NS records found for %s*\n", *domain)
-				for _, n := range ns {
-					messageText += fmt.Sprintf("  - `%s`\n", n.Host)
-				}
-				if err := sendTelegramMessage(botToken, chatID, messageText); err != nil {
-					log.Printf("Error sending Telegram notification: %v", err)
-				}
-				state.nsRecordFound = true
-			}
-		}
+	sched := scheduler.New(reg, botToken, extraNotifiers, defaultInterval, maxInterval)
+	b := bot.New(botToken, allowedChatIDs, reg, sched)
+	sched.Start()
 
-		// Check for MX records if not already found
-		if !state.mxRecordFound {
-			log.Printf("Checking MX records for %s...", *domain)
-			mx, err := resolver.LookupMX(context.Background(), *domain)
-			if err == nil && len(mx) > 0 {
-				log.Println("Found MX records! Sending notification.")
-				messageText := fmt.Sprintf("✅ *MX records found for %s*\n", *domain)
-				for _, m := range mx {
-					messageText += fmt.Sprintf("  - Host: `%s`, Pref: %d\n", m.Host, m.Pref)
-				}
-				if err := sendTelegramMessage(botToken, chatID, messageText); err != nil {
-					log.Printf("Error sending Telegram notification: %v", err)
-				}
-				state.mxRecordFound = true
-			}
+	if listen != "" {
+		baseURL := os.Getenv("BASE_URL")
+		if baseURL == "" {
+			log.Fatal("Error: BASE_URL must be set when --listen is used.")
 		}
-
-		<-ticker.C
+		secret, err := bot.GenerateWebhookSecret()
+		if err != nil {
+			log.Fatalf("Error generating webhook secret: %v", err)
+		}
+		if err := bot.RegisterWebhook(botToken, baseURL, secret); err != nil {
+			log.Fatalf("Error registering webhook: %v", err)
+		}
+		b.WebhookSecret = secret
+		http.HandleFunc("/telegram/webhook", b.WebhookHandler())
+		log.Printf("Serving Telegram webhook on %s (public URL %s).", listen, baseURL)
+		log.Fatal(http.ListenAndServe(listen, nil))
+		return
 	}
+
+	log.Println("Polling Telegram for commands via getUpdates.")
+	b.RunPolling()
 }
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/backoff"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/config"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/discovery"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/message"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/notifier"
+	"github.com/H4mid2019/dns_ropagation_check_telegram_notify/internal/resolver"
+)
+
+// runDomainWatch polls one domain until every configured record type has
+// reached full quorum (or, if expectations are declared, matches them) or
+// its deadline passes, notifying notifiers along the way. Both the legacy
+// --domain flags and each entry under --config's `domains:` list run
+// through this same function.
+func runDomainWatch(ctx context.Context, dc config.DomainConfig, notifiers []notifier.Notifier, checkAuthoritative bool) {
+	recordTypes := dc.RecordTypes
+	if len(recordTypes) == 0 {
+		recordTypes = resolver.DefaultTypes
+	}
+	dnsServers := dc.DNSServers
+	if dnsServers == "" {
+		dnsServers = "1.1.1.1:53"
+	}
+	intervalMinutes := dc.IntervalMinutes
+	if intervalMinutes <= 0 {
+		intervalMinutes = 60
+	}
+	checkInterval := time.Duration(intervalMinutes) * time.Minute
+
+	maxInterval := 24 * time.Hour
+	if dc.MaxIntervalMinutes > 0 {
+		maxInterval = time.Duration(dc.MaxIntervalMinutes) * time.Minute
+	}
+
+	var deadline time.Time
+	if dc.Deadline != "" {
+		var err error
+		deadline, err = time.Parse(time.RFC3339, dc.Deadline)
+		if err != nil {
+			log.Printf("Ignoring unparsable deadline %q for %s: %v", dc.Deadline, dc.Domain, err)
+		}
+	}
+
+	resolvers := resolver.ParseResolvers(dnsServers)
+	if len(resolvers) == 0 {
+		log.Printf("%s has no usable DNS resolvers in %q, skipping it.", dc.Domain, dnsServers)
+		return
+	}
+	if checkAuthoritative {
+		authResolvers, err := resolver.AuthoritativeNameservers(ctx, resolvers[0].Address, dc.Domain)
+		if err != nil {
+			log.Printf("Could not resolve authoritative nameservers for %s, skipping them: %v", dc.Domain, err)
+		} else {
+			resolvers = append(resolvers, authResolvers...)
+		}
+	}
+
+	state := discovery.New(dc.Domain, resolvers, recordTypes)
+	hasExpectations := recordTypesWithExpectations(dc.Expected, recordTypes)
+	pace := backoff.New(checkInterval, maxInterval)
+
+	log.Printf("Starting DNS watch for %s across %d resolvers, checking every %v (max %v on backoff).", dc.Domain, len(resolvers), checkInterval, maxInterval)
+
+	for {
+		pollCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		newlyComplete := state.Poll(pollCtx)
+		cancel()
+
+		notifyCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		for _, rt := range newlyComplete {
+			ev := notifier.Event{Domain: dc.Domain, Subject: fmt.Sprintf("%s records propagated", rt), Body: message.FormatRecordFound(state, rt)}
+			if err := notifier.NotifyAll(notifyCtx, notifiers, ev); err != nil {
+				log.Printf("Error notifying for %s: %v", dc.Domain, err)
+			}
+		}
+
+		var done bool
+		if len(hasExpectations) > 0 {
+			log.Print(message.FormatExpectedStatus(state, dc.Expected))
+			done = state.AllExpectationsMatch(dc.Expected, hasExpectations)
+		} else {
+			log.Print(message.FormatProgress(state))
+			done = state.FullyPropagated()
+		}
+
+		if done {
+			log.Printf("%s is done: all tracked records are in the expected state.", dc.Domain)
+			notifier.NotifyAll(notifyCtx, notifiers, notifier.Event{
+				Domain:  dc.Domain,
+				Subject: "Fully propagated",
+				Body:    fmt.Sprintf("✅ *%s has fully propagated!*", dc.Domain),
+			})
+			cancel()
+			return
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			log.Printf("%s hit its deadline (%s) without fully propagating.", dc.Domain, dc.Deadline)
+			notifier.NotifyAll(notifyCtx, notifiers, notifier.Event{
+				Domain:  dc.Domain,
+				Subject: "Deadline passed",
+				Body:    fmt.Sprintf("⏰ *%s did not finish propagating before its deadline (%s).*", dc.Domain, dc.Deadline),
+			})
+			cancel()
+			return
+		}
+
+		transientErr, negativeTTL := state.PacingHint()
+		wait, shouldWarn := pace.Next(transientErr, negativeTTL)
+		if shouldWarn {
+			log.Printf("%s has failed %d consecutive checks; still retrying.", dc.Domain, backoff.FailureWarningThreshold)
+			notifier.NotifyAll(notifyCtx, notifiers, notifier.Event{
+				Domain:  dc.Domain,
+				Subject: "Repeated check failures",
+				Body:    fmt.Sprintf("⚠️ *%s has failed %d consecutive DNS checks.* Still retrying with backoff.", dc.Domain, backoff.FailureWarningThreshold),
+			})
+		}
+		cancel()
+		log.Printf("Next check for %s in %v.", dc.Domain, wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// recordTypesWithExpectations returns the subset of recordTypes that have
+// an `expected:` entry configured.
+func recordTypesWithExpectations(exp discovery.ExpectedRecords, recordTypes []resolver.RecordType) []resolver.RecordType {
+	var out []resolver.RecordType
+	for _, rt := range recordTypes {
+		if discovery.HasExpectation(rt, exp) {
+			out = append(out, rt)
+		}
+	}
+	return out
+}